@@ -6,7 +6,10 @@ import (
 	"github.com/robinliucn/filebrowser/v2/users"
 )
 
-// Auther is the authentication interface.
+// Auther is the authentication interface. Built-in and third-party
+// implementations register a factory for building one from its persisted
+// JSON config via Register, instead of being wired in by a fixed config
+// string; see New.
 type Auther interface {
 	// Auth is called to authenticate a request.
 	Auth(r *http.Request, s users.Store, root string) (*users.User, error)
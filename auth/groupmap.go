@@ -0,0 +1,34 @@
+package auth
+
+import "github.com/robinliucn/filebrowser/v2/users"
+
+// GroupRule maps a single external group membership (an OIDC claim value or
+// an LDAP group DN/CN, depending on the Auther) to the scope and
+// permissions a user gets on first login.
+type GroupRule struct {
+	Group      string            `json:"group"`
+	Scope      string            `json:"scope"`
+	Permission users.Permissions `json:"permission"`
+}
+
+// ApplyGroupRules sets u's scope and permissions from the first rule whose
+// Group matches one of the caller's groups, leaving u untouched if none
+// match. Rules are evaluated in order, so more specific rules should come
+// first.
+func ApplyGroupRules(u *users.User, groups []string, rules []GroupRule) {
+	memberOf := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		memberOf[g] = true
+	}
+
+	for _, rule := range rules {
+		if !memberOf[rule.Group] {
+			continue
+		}
+		if rule.Scope != "" {
+			u.Scope = rule.Scope
+		}
+		u.Perm = rule.Permission
+		return
+	}
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/robinliucn/filebrowser/v2/users"
+)
+
+func init() {
+	Register("ldap", func(raw json.RawMessage) (Auther, error) {
+		var cfg LdapConfig
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return nil, err
+		}
+		return NewLdapAuther(cfg), nil
+	})
+}
+
+// LdapConfig is the JSON config blob persisted by storage.Storage for the
+// "ldap" auth method.
+type LdapConfig struct {
+	Host   string `json:"host"`
+	Port   int    `json:"port"`
+	UseTLS bool   `json:"useTls"`
+	// StartTLS upgrades a plaintext connection instead of dialing a TLS
+	// port directly; ignored if UseTLS is already set.
+	StartTLS bool `json:"startTls"`
+
+	// BindDNTemplate is the user bind DN, with "%s" substituted for the
+	// submitted username, e.g. "uid=%s,ou=people,dc=example,dc=com".
+	BindDNTemplate string `json:"bindDnTemplate"`
+
+	// BaseDN and GroupSearchFilter locate the groups a user belongs to;
+	// "%s" in the filter is substituted for the user's DN.
+	BaseDN            string `json:"baseDn"`
+	GroupSearchFilter string `json:"groupSearchFilter"`
+	GroupAttribute    string `json:"groupAttribute"`
+
+	GroupRules    []GroupRule `json:"groupRules"`
+	AutoProvision bool        `json:"autoProvision"`
+}
+
+// LdapAuther authenticates users by binding to an LDAP/Active Directory
+// server with credentials from HTTP basic auth, then looks up the user's
+// group memberships to map them to filebrowser scope/permissions.
+type LdapAuther struct {
+	cfg LdapConfig
+}
+
+// NewLdapAuther returns a ready to use LdapAuther. Config fields default
+// to a sensible GroupAttribute when left blank.
+func NewLdapAuther(cfg LdapConfig) *LdapAuther {
+	if cfg.GroupAttribute == "" {
+		cfg.GroupAttribute = "cn"
+	}
+	return &LdapAuther{cfg: cfg}
+}
+
+// LoginPage reports that filebrowser's normal username/password login form
+// should be used; credentials are forwarded to LDAP via Auth.
+func (a *LdapAuther) LoginPage() bool {
+	return true
+}
+
+// Auth binds to LDAP as the submitted user, then, on success, searches for
+// the user's groups and maps them to a users.User.
+func (a *LdapAuther) Auth(r *http.Request, s users.Store, _ string) (*users.User, error) {
+	username, password, ok := r.BasicAuth()
+	if !ok || username == "" || password == "" {
+		return nil, fmt.Errorf("auth: ldap requires basic auth credentials")
+	}
+
+	conn, err := a.dial()
+	if err != nil {
+		return nil, fmt.Errorf("auth: ldap connect failed: %w", err)
+	}
+	defer conn.Close()
+
+	bindDN := fmt.Sprintf(a.cfg.BindDNTemplate, escapeDN(username))
+	if err := conn.Bind(bindDN, password); err != nil {
+		return nil, fmt.Errorf("auth: ldap bind failed: %w", err)
+	}
+
+	groups, err := a.groupsFor(conn, bindDN)
+	if err != nil {
+		return nil, fmt.Errorf("auth: ldap group search failed: %w", err)
+	}
+
+	u, err := s.Get("", username)
+	isNewUser := errors.Is(err, users.ErrNotExist)
+	switch {
+	case err == nil:
+		// existing user, fall through
+	case isNewUser:
+		if !a.cfg.AutoProvision {
+			return nil, err
+		}
+		u = &users.User{Username: username}
+	default:
+		// transient store error: don't treat it as "new user" and risk
+		// overwriting an existing one's scope/permissions.
+		return nil, fmt.Errorf("auth: ldap user lookup failed: %w", err)
+	}
+
+	// Only map group rules on first provisioning: an existing user may have
+	// had their scope/permissions changed by hand in the UI since, and a
+	// routine LDAP login shouldn't silently overwrite that.
+	if isNewUser {
+		ApplyGroupRules(u, groups, a.cfg.GroupRules)
+		if err := s.Save(u); err != nil {
+			return nil, err
+		}
+	}
+
+	return u, nil
+}
+
+func (a *LdapAuther) dial() (*ldap.Conn, error) {
+	addr := fmt.Sprintf("%s:%d", a.cfg.Host, a.cfg.Port)
+
+	if a.cfg.UseTLS {
+		return ldap.DialURL(fmt.Sprintf("ldaps://%s", addr), ldap.DialWithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS12}))
+	}
+
+	conn, err := ldap.DialURL(fmt.Sprintf("ldap://%s", addr))
+	if err != nil {
+		return nil, err
+	}
+	if a.cfg.StartTLS {
+		if err := conn.StartTLS(&tls.Config{MinVersion: tls.VersionTLS12}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// escapeDN escapes v for safe substitution into an RFC 4514 distinguished
+// name, e.g. BindDNTemplate. This is deliberately not ldap.EscapeFilter:
+// that escapes for search-filter syntax (parentheses, asterisks, ...), not
+// DN syntax, so a username containing a comma or "+" would still alter the
+// DN structure fmt.Sprintf builds.
+func escapeDN(v string) string {
+	var b strings.Builder
+	for i, r := range v {
+		switch {
+		case i == 0 && (r == ' ' || r == '#'):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case i == len(v)-1 && r == ' ':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case strings.ContainsRune(`,+"\<>;=`, r):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == 0:
+			b.WriteString(`\00`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (a *LdapAuther) groupsFor(conn *ldap.Conn, userDN string) ([]string, error) {
+	filter := a.cfg.GroupSearchFilter
+	if filter == "" {
+		filter = "(member=%s)"
+	}
+	filter = strings.ReplaceAll(filter, "%s", ldap.EscapeFilter(userDN))
+
+	req := ldap.NewSearchRequest(
+		a.cfg.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		filter,
+		[]string{a.cfg.GroupAttribute},
+		nil,
+	)
+
+	res, err := conn.Search(req)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]string, 0, len(res.Entries))
+	for _, entry := range res.Entries {
+		if v := entry.GetAttributeValue(a.cfg.GroupAttribute); v != "" {
+			groups = append(groups, v)
+		}
+	}
+	return groups, nil
+}
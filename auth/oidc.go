@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+
+	"github.com/robinliucn/filebrowser/v2/users"
+)
+
+// sessionTTL bounds how long a pending PKCE exchange is kept around;
+// abandoned login attempts are swept by the next call to AuthCodeURL.
+const sessionTTL = 10 * time.Minute
+
+// ErrOidcCallback is returned when the callback request is missing the
+// code/state pair or refers to a state filebrowser never issued.
+var ErrOidcCallback = errors.New("auth: invalid oidc callback")
+
+func init() {
+	Register("oidc", func(raw json.RawMessage) (Auther, error) {
+		var cfg OidcConfig
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return nil, err
+		}
+		return NewOidcAuther(cfg)
+	})
+}
+
+// OidcConfig is the JSON config blob persisted by storage.Storage for the
+// "oidc" auth method.
+type OidcConfig struct {
+	IssuerURL     string      `json:"issuerUrl"`
+	ClientID      string      `json:"clientId"`
+	ClientSecret  string      `json:"clientSecret"`
+	RedirectURL   string      `json:"redirectUrl"`
+	Scopes        []string    `json:"scopes"`
+	GroupsClaim   string      `json:"groupsClaim"`
+	UsernameClaim string      `json:"usernameClaim"`
+	GroupRules    []GroupRule `json:"groupRules"`
+	AutoProvision bool        `json:"autoProvision"`
+}
+
+// OidcAuther authenticates users against an OIDC provider using the
+// authorization-code flow with PKCE. It never prompts for a filebrowser
+// password; LoginPage reports false, since the user logs in at the
+// provider rather than on filebrowser's own login page.
+type OidcAuther struct {
+	cfg      OidcConfig
+	provider *oidc.Provider
+	verifier *oidc.IDTokenVerifier
+	oauth    oauth2.Config
+
+	mu       sync.Mutex
+	sessions map[string]pkceSession // state -> verifier, for the callback round-trip
+}
+
+type pkceSession struct {
+	verifier  string
+	createdAt time.Time
+}
+
+// NewOidcAuther discovers the provider's metadata and JWKS (cached by the
+// oidc package) and returns a ready to use OidcAuther.
+func NewOidcAuther(cfg OidcConfig) (*OidcAuther, error) {
+	provider, err := oidc.NewProvider(context.Background(), cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("auth: oidc discovery failed: %w", err)
+	}
+
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = "preferred_username"
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+
+	return &OidcAuther{
+		cfg:      cfg,
+		provider: provider,
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		oauth: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+		sessions: map[string]pkceSession{},
+	}, nil
+}
+
+// LoginPage reports that filebrowser's own login form is not needed: the
+// user logs in at the provider instead.
+func (a *OidcAuther) LoginPage() bool {
+	return false
+}
+
+// AuthCodeURL generates a PKCE verifier/challenge pair, remembers it under
+// a fresh state value, and returns the provider URL the frontend should
+// redirect the browser to. The matching call to Auth on the callback
+// request looks the verifier back up by state.
+func (a *OidcAuther) AuthCodeURL() (string, error) {
+	state, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	a.mu.Lock()
+	a.sessions[state] = pkceSession{verifier: verifier, createdAt: time.Now()}
+	a.expireSessionsLocked()
+	a.mu.Unlock()
+
+	challenge := oauth2.S256ChallengeFromVerifier(verifier)
+	return a.oauth.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("code_challenge", challenge),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), nil
+}
+
+// expireSessionsLocked drops PKCE sessions older than sessionTTL. Callers
+// must hold a.mu.
+func (a *OidcAuther) expireSessionsLocked() {
+	for state, session := range a.sessions {
+		if time.Since(session.createdAt) > sessionTTL {
+			delete(a.sessions, state)
+		}
+	}
+}
+
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Auth exchanges the authorization code on the callback request for an ID
+// token, verifies it, and maps the resulting claims to a users.User.
+func (a *OidcAuther) Auth(r *http.Request, s users.Store, _ string) (*users.User, error) {
+	code := r.URL.Query().Get("code")
+	state := r.URL.Query().Get("state")
+	if code == "" || state == "" {
+		return nil, ErrOidcCallback
+	}
+
+	a.mu.Lock()
+	session, ok := a.sessions[state]
+	delete(a.sessions, state)
+	a.mu.Unlock()
+	if !ok {
+		return nil, ErrOidcCallback
+	}
+
+	token, err := a.oauth.Exchange(r.Context(), code, oauth2.SetAuthURLParam("code_verifier", session.verifier))
+	if err != nil {
+		return nil, fmt.Errorf("auth: oidc token exchange failed: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("auth: oidc token response missing id_token")
+	}
+
+	idToken, err := a.verifier.Verify(r.Context(), rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("auth: oidc id_token verification failed: %w", err)
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	username, _ := claims[a.cfg.UsernameClaim].(string)
+	if username == "" {
+		return nil, fmt.Errorf("auth: oidc claims missing %q", a.cfg.UsernameClaim)
+	}
+
+	groups := claimStrings(claims[a.cfg.GroupsClaim])
+
+	u, err := s.Get("", username)
+	isNewUser := errors.Is(err, users.ErrNotExist)
+	switch {
+	case err == nil:
+		// existing user, fall through
+	case isNewUser:
+		if !a.cfg.AutoProvision {
+			return nil, err
+		}
+		u = &users.User{Username: username}
+	default:
+		// transient store error: don't treat it as "new user" and risk
+		// overwriting an existing one's scope/permissions.
+		return nil, fmt.Errorf("auth: oidc user lookup failed: %w", err)
+	}
+
+	// Only map group rules on first provisioning: an existing user may have
+	// had their scope/permissions changed by hand in the UI since, and a
+	// routine SSO login shouldn't silently overwrite that.
+	if isNewUser {
+		ApplyGroupRules(u, groups, a.cfg.GroupRules)
+		if err := s.Save(u); err != nil {
+			return nil, err
+		}
+	}
+
+	return u, nil
+}
+
+func claimStrings(v interface{}) []string {
+	raw, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]string, 0, len(raw))
+	for _, item := range raw {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Factory builds an Auther from its raw JSON config blob, as persisted by
+// storage.Storage. Each built-in and third-party Auther registers one under
+// a unique name.
+type Factory func(raw json.RawMessage) (Auther, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register adds an Auther factory under name, so it can be selected by
+// name from settings. Intended to be called from an init() function of the
+// package implementing the Auther; registering the same name twice panics,
+// mirroring how database/sql drivers are registered.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("auth: Register called twice for method %q", name))
+	}
+	registry[name] = factory
+}
+
+// New looks up the Auther registered under name and builds it from raw.
+func New(name string, raw json.RawMessage) (Auther, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("auth: no Auther registered under method %q", name)
+	}
+	return factory(raw)
+}
+
+// Methods returns the names of all currently registered Authers. The order
+// is not guaranteed; callers that need a stable order should sort it
+// themselves.
+func Methods() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	return names
+}
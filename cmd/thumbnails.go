@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/spf13/cobra"
+
+	"github.com/robinliucn/filebrowser/v2/filecache"
+	fbhttp "github.com/robinliucn/filebrowser/v2/http"
+	"github.com/robinliucn/filebrowser/v2/img"
+	"github.com/robinliucn/filebrowser/v2/video"
+)
+
+var previewableImageExt = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true,
+}
+
+var previewableVideoExt = map[string]bool{
+	".mp4": true, ".mkv": true, ".mov": true, ".webm": true,
+}
+
+var (
+	thumbnailsWorkers          int
+	thumbnailsCacheDir         string
+	thumbnailsCacheSize        int64
+	thumbnailsVideoFrameOffset float64
+)
+
+var thumbnailsCmd = &cobra.Command{
+	Use:   "thumbnails",
+	Short: "Manage the preview thumbnail cache",
+}
+
+var thumbnailsGenerateCmd = &cobra.Command{
+	Use:   "generate [path]",
+	Short: "Pre-generate thumb and big previews for a directory tree",
+	Long: `generate walks path, filters files down to the extensions the preview
+pipeline understands (images and videos), and resizes a thumb and a big
+variant of each into the on-disk cache so first page load of a large
+gallery isn't a stampede on the resize pipeline. Runs with a bounded
+worker pool rather than firing every file at once.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cache, err := filecache.NewDiskCache(thumbnailsCacheDir, thumbnailsCacheSize)
+		checkErr(err)
+		defer cache.Close()
+
+		imgSvc := img.New()
+
+		videoSvc, err := video.NewService()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "thumbnails: video previews disabled: %v\n", err)
+			videoSvc = nil
+		}
+
+		paths := make(chan string, 256)
+		var generated, failed int64
+		var wg sync.WaitGroup
+
+		for i := 0; i < thumbnailsWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for path := range paths {
+					if err := generateThumbnails(imgSvc, videoSvc, cache, args[0], path, thumbnailsVideoFrameOffset); err != nil {
+						fmt.Fprintf(os.Stderr, "thumbnails: %s: %v\n", path, err)
+						atomic.AddInt64(&failed, 1)
+						continue
+					}
+					atomic.AddInt64(&generated, 1)
+				}
+			}()
+		}
+
+		walkErr := filepath.Walk(args[0], func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			if info.IsDir() || !(previewableImageExt[ext] || previewableVideoExt[ext]) {
+				return nil
+			}
+			paths <- path
+			return nil
+		})
+		close(paths)
+		wg.Wait()
+
+		checkErr(walkErr)
+		fmt.Printf("thumbnails: generated %d, failed %d\n", generated, failed)
+	},
+}
+
+// generateThumbnails resizes both the thumb and big variants of path and
+// stores them in cache, keyed by path+mtime+size the same way the preview
+// handler does. The key uses path relative to root, which is how the
+// preview handler sees the file for a user whose scope is root, so a later
+// browser request from such a user is a cache hit.
+func generateThumbnails(imgSvc *img.Service, videoSvc *video.Service, cache *filecache.DiskCache, root, path string, videoFrameOffset float64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return err
+	}
+	keyPath := "/" + filepath.ToSlash(rel)
+
+	var source []byte
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case previewableImageExt[ext]:
+		source, err = os.ReadFile(path)
+	case previewableVideoExt[ext]:
+		if videoSvc == nil {
+			return fmt.Errorf("video previews are disabled")
+		}
+		fd, openErr := os.Open(path)
+		if openErr != nil {
+			return openErr
+		}
+		defer fd.Close()
+		frame := &bytes.Buffer{}
+		err = videoSvc.Frame(context.Background(), fd, videoFrameOffset, frame)
+		source = frame.Bytes()
+	default:
+		return fmt.Errorf("unsupported extension %q", ext)
+	}
+	if err != nil {
+		return err
+	}
+
+	sizes := map[string][2]int{"thumb": {128, 128}, "big": {1080, 1080}}
+	for name, dims := range sizes {
+		previewSize, err := fbhttp.ParsePreviewSize(name)
+		if err != nil {
+			return err
+		}
+
+		buf := &bytes.Buffer{}
+		if err := imgSvc.Resize(context.Background(), bytes.NewReader(source), dims[0], dims[1], buf); err != nil {
+			return err
+		}
+		// transform is nil: generate only pre-populates the plain preview,
+		// not every possible crop/rotate variant.
+		key := fbhttp.PreviewCacheKey(keyPath, info.ModTime().Unix(), previewSize, nil)
+		if err := cache.Store(context.Background(), key, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	thumbnailsGenerateCmd.Flags().IntVar(&thumbnailsWorkers, "workers", 4, "number of concurrent generation workers")
+	thumbnailsGenerateCmd.Flags().StringVar(&thumbnailsCacheDir, "cache-dir", "", "thumbnail cache directory (no default; must be set)")
+	thumbnailsGenerateCmd.Flags().Int64Var(&thumbnailsCacheSize, "cache-size", 2<<30, "thumbnail cache byte budget in bytes (default 2GB)")
+	thumbnailsGenerateCmd.Flags().Float64Var(&thumbnailsVideoFrameOffset, "video-frame-offset", 0.1, "how far into a video to grab its still frame, as a fraction of duration (0.0-1.0)")
+	thumbnailsCmd.AddCommand(thumbnailsGenerateCmd)
+	rootCmd.AddCommand(thumbnailsCmd)
+}
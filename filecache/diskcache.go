@@ -0,0 +1,209 @@
+// Package filecache implements http.FileCache backends.
+package filecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var entriesBucket = []byte("entries")
+
+// entry is the bookkeeping record kept in the BoltDB index for every file on
+// disk, so the janitor can decide what to evict without stat-ing every file.
+type entry struct {
+	Size  int64
+	Atime int64
+	Shard string
+}
+
+// DiskCache is an http.FileCache backed by a sharded directory tree on disk,
+// bounded by a total byte budget and evicted least-recently-used first.
+// Access times are tracked in a BoltDB index rather than relying on the
+// filesystem's atime, which is frequently mounted noatime.
+type DiskCache struct {
+	dir       string
+	budget    int64
+	db        *bolt.DB
+	janitorMu sync.Mutex
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+// NewDiskCache creates (or reopens) a disk-backed cache rooted at dir with
+// the given byte budget and starts its background janitor.
+func NewDiskCache(dir string, budget int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(filepath.Join(dir, "index.db"), 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(entriesBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	dc := &DiskCache{
+		dir:    dir,
+		budget: budget,
+		db:     db,
+		done:   make(chan struct{}),
+	}
+	go dc.janitorLoop()
+	return dc, nil
+}
+
+// Close stops the janitor and closes the index.
+func (d *DiskCache) Close() error {
+	d.closeOnce.Do(func() { close(d.done) })
+	return d.db.Close()
+}
+
+func (d *DiskCache) shardedPath(key string) (dir, path string) {
+	sum := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(sum[:])
+	dir = filepath.Join(d.dir, hash[:2], hash[2:4])
+	path = filepath.Join(dir, hash)
+	return dir, path
+}
+
+// Store writes value to its sharded path and records it in the index.
+func (d *DiskCache) Store(_ context.Context, key string, value []byte) error {
+	dir, path := d.shardedPath(key)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, value, 0o644); err != nil {
+		return err
+	}
+
+	return d.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(entriesBucket)
+		e := entry{Size: int64(len(value)), Atime: time.Now().Unix(), Shard: path}
+		return b.Put([]byte(key), encodeEntry(e))
+	})
+}
+
+// Load reads the value for key, bumping its access time for LRU purposes.
+func (d *DiskCache) Load(_ context.Context, key string) ([]byte, bool, error) {
+	_, path := d.shardedPath(key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	_ = d.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(entriesBucket)
+		e := entry{Size: int64(len(data)), Atime: time.Now().Unix(), Shard: path}
+		return b.Put([]byte(key), encodeEntry(e))
+	})
+
+	return data, true, nil
+}
+
+// Delete removes the cached value for key, if any.
+func (d *DiskCache) Delete(_ context.Context, key string) error {
+	_, path := d.shardedPath(key)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(entriesBucket).Delete([]byte(key))
+	})
+}
+
+// Stats is a point-in-time snapshot of cache occupancy, exposed through the
+// admin API so operators can see whether the budget is being hit.
+type Stats struct {
+	Entries   int
+	TotalSize int64
+	Budget    int64
+}
+
+// Stats reports the current entry count and total size of the cache.
+func (d *DiskCache) Stats() (Stats, error) {
+	stats := Stats{Budget: d.budget}
+	err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(entriesBucket).ForEach(func(_, v []byte) error {
+			stats.Entries++
+			stats.TotalSize += decodeEntry(v).Size
+			return nil
+		})
+	})
+	return stats, err
+}
+
+// janitorLoop periodically evicts the least-recently-used entries until the
+// cache is back under budget.
+func (d *DiskCache) janitorLoop() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-ticker.C:
+			if err := d.evictToBudget(); err != nil {
+				fmt.Printf("filecache: janitor eviction failed: %v\n", err)
+			}
+		}
+	}
+}
+
+func (d *DiskCache) evictToBudget() error {
+	d.janitorMu.Lock()
+	defer d.janitorMu.Unlock()
+
+	var all []keyedEntry
+	var total int64
+
+	if err := d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(entriesBucket).ForEach(func(k, v []byte) error {
+			e := decodeEntry(v)
+			all = append(all, keyedEntry{key: string(k), entry: e})
+			total += e.Size
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+
+	if total <= d.budget {
+		return nil
+	}
+
+	sortByAtimeAsc(all)
+
+	for _, kv := range all {
+		if total <= d.budget {
+			break
+		}
+		if err := os.Remove(kv.entry.Shard); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err := d.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(entriesBucket).Delete([]byte(kv.key))
+		}); err != nil {
+			return err
+		}
+		total -= kv.entry.Size
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package filecache
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// encodeEntry/decodeEntry use a tiny fixed layout instead of a generic
+// encoder since entry only ever holds two int64s and a path.
+func encodeEntry(e entry) []byte {
+	buf := make([]byte, 8+8+len(e.Shard))
+	binary.BigEndian.PutUint64(buf[0:8], uint64(e.Size))
+	binary.BigEndian.PutUint64(buf[8:16], uint64(e.Atime))
+	copy(buf[16:], e.Shard)
+	return buf
+}
+
+func decodeEntry(b []byte) entry {
+	if len(b) < 16 {
+		return entry{}
+	}
+	return entry{
+		Size:  int64(binary.BigEndian.Uint64(b[0:8])),
+		Atime: int64(binary.BigEndian.Uint64(b[8:16])),
+		Shard: string(b[16:]),
+	}
+}
+
+// keyedEntry pairs an index entry with the cache key it belongs to, so the
+// janitor can sort by access time and still know what to delete.
+type keyedEntry struct {
+	key   string
+	entry entry
+}
+
+func sortByAtimeAsc(all []keyedEntry) {
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].entry.Atime < all[j].entry.Atime
+	})
+}
@@ -0,0 +1,35 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/robinliucn/filebrowser/v2/filecache"
+)
+
+// cacheStatser is implemented by FileCache backends that can report
+// occupancy, so the admin handler degrades gracefully for simpler caches.
+type cacheStatser interface {
+	Stats() (filecache.Stats, error)
+}
+
+// cacheStatsHandler exposes the thumbnail cache's occupancy to the admin
+// surface, so operators can see whether the configured budget is enough.
+func cacheStatsHandler(fileCache FileCache) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if !d.user.Perm.Admin {
+			return http.StatusForbidden, nil
+		}
+
+		statser, ok := fileCache.(cacheStatser)
+		if !ok {
+			return http.StatusNotImplemented, nil
+		}
+
+		stats, err := statser.Stats()
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		return renderJSON(w, r, stats)
+	})
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gorilla/mux"
+	"github.com/spf13/afero"
+
+	"github.com/robinliucn/filebrowser/v2/files"
+	"github.com/robinliucn/filebrowser/v2/img"
+)
+
+// transformFileHandler serves POST /api/files/{path}/transform. Unlike the
+// preview endpoints, which only ever touch the cache, this persists the
+// crop/rotate edit back to the original file for users with Perm.Modify,
+// writing through a temp file + rename so a crash mid-write can't corrupt
+// the original, then deletes the path's cached plain thumb and big
+// previews. Cached crop/rotate variants of the path are not deleted.
+func transformFileHandler(imgSvc ImgService, fileCache FileCache) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if !d.user.Perm.Download || !d.user.Perm.Modify {
+			return http.StatusForbidden, nil
+		}
+
+		transform, err := parsePreviewTransform(r)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		if transform == nil {
+			return http.StatusBadRequest, nil
+		}
+
+		vars := mux.Vars(r)
+		file, err := files.NewFileInfo(files.FileOptions{
+			Fs:         d.user.Fs,
+			Path:       "/" + vars["path"],
+			Modify:     d.user.Perm.Modify,
+			Expand:     false,
+			ReadHeader: d.server.TypeDetectionByHeader,
+			Checker:    d,
+		})
+		if err != nil {
+			return errToStatus(err), err
+		}
+		oldModTime := file.ModTime.Unix()
+
+		if err := applyTransform(r.Context(), imgSvc, file, transform); err != nil {
+			return errToStatus(err), err
+		}
+
+		if err := invalidatePreviewCache(r.Context(), fileCache, file.Path, oldModTime); err != nil {
+			return errToStatus(err), err
+		}
+
+		return http.StatusOK, nil
+	})
+}
+
+// applyTransform resizes (crops/rotates) file.Path in place: it reads the
+// original, writes the transformed image into a temp file in the same
+// directory, then renames the temp file over the original so readers never
+// see a partially-written file.
+func applyTransform(ctx context.Context, imgSvc ImgService, file *files.FileInfo, transform *previewTransform) error {
+	src, err := file.Fs.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp, err := afero.TempFile(file.Fs, filepath.Dir(file.Path), ".filebrowser-transform-*"+file.Extension)
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer file.Fs.Remove(tmpPath) // no-op once the rename below succeeds
+
+	options := append([]img.Option{img.WithAutoOrient(true)}, transform.options()...)
+	// width=0, height=0: keep the transformed image at its native
+	// resolution rather than resizing it for a particular preview size.
+	err = imgSvc.Resize(ctx, src, 0, 0, tmp, options...)
+	closeErr := tmp.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+
+	return file.Fs.Rename(tmpPath, file.Path)
+}
+
+// invalidatePreviewCache deletes the thumb and big cache entries keyed
+// under the pre-transform mtime, so stale cached previews don't linger
+// once the underlying file has changed. Crop/rotate variants are keyed
+// separately and are left in the cache.
+func invalidatePreviewCache(ctx context.Context, fileCache FileCache, path string, oldModTime int64) error {
+	for _, size := range []PreviewSize{PreviewSizeThumb, PreviewSizeBig} {
+		key := PreviewCacheKey(path, oldModTime, size, nil)
+		if err := fileCache.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
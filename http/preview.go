@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 
@@ -27,13 +28,45 @@ type ImgService interface {
 	Resize(ctx context.Context, in io.Reader, width, height int, out io.Writer, options ...img.Option) error
 }
 
+// VideoService extracts a still frame from a video file so it can be run
+// through the same resize pipeline as a regular image.
+type VideoService interface {
+	// Frame writes a JPEG-encoded frame taken near the given offset
+	// (0.0-1.0, fraction of the video duration) to out.
+	Frame(ctx context.Context, in io.Reader, offset float64, out io.Writer) error
+}
+
+// PDFService rasterizes the first page of a PDF document to an image so it
+// can be run through the same resize pipeline as a regular image.
+type PDFService interface {
+	FirstPage(ctx context.Context, in io.Reader, out io.Writer) error
+}
+
 type FileCache interface {
 	Store(ctx context.Context, key string, value []byte) error
 	Load(ctx context.Context, key string) ([]byte, bool, error)
 	Delete(ctx context.Context, key string) error
 }
 
-func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, resizePreview bool) handleFunc {
+// previewable extensions for the types that don't get their preview from
+// files.FileInfo.Type directly.
+var videoExtensions = map[string]bool{
+	".mp4":  true,
+	".mkv":  true,
+	".mov":  true,
+	".webm": true,
+}
+
+// defaultVideoFrameOffset is how far into the video VideoService.Frame
+// grabs its still, expressed as a fraction of the video duration, used
+// when the caller doesn't configure one.
+const defaultVideoFrameOffset = 0.1
+
+func previewHandler(imgSvc ImgService, videoSvc VideoService, pdfSvc PDFService, fileCache FileCache,
+	enableThumbnails, resizePreview, enableVideoPreview, enablePdfPreview, preserveExif bool, videoFrameOffset float64) handleFunc {
+	if videoFrameOffset <= 0 || videoFrameOffset > 1 {
+		videoFrameOffset = defaultVideoFrameOffset
+	}
 	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		if !d.user.Perm.Download {
 			return http.StatusAccepted, nil
@@ -45,6 +78,16 @@ func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, re
 			return http.StatusBadRequest, err
 		}
 
+		transform, err := parsePreviewTransform(r)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		// Crop/rotate is a lightweight edit, not a download; allow it on a
+		// read-only file via Perm.Preview instead of requiring Perm.Modify.
+		if transform != nil && !d.user.Perm.Modify && !d.user.Perm.Preview {
+			return http.StatusForbidden, nil
+		}
+
 		file, err := files.NewFileInfo(files.FileOptions{
 			Fs:         d.user.Fs,
 			Path:       "/" + vars["path"],
@@ -59,9 +102,19 @@ func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, re
 
 		setContentDisposition(w, r, file)
 
-		switch file.Type {
-		case "image":
-			return handleImagePreview(w, r, imgSvc, fileCache, file, previewSize, enableThumbnails, resizePreview)
+		switch {
+		case file.Type == "image":
+			return handleImagePreview(w, r, imgSvc, fileCache, file, previewSize, enableThumbnails, resizePreview, preserveExif, transform)
+		case file.Type == "video" && videoExtensions[strings.ToLower(file.Extension)]:
+			if !enableVideoPreview || videoSvc == nil {
+				return http.StatusNotImplemented, fmt.Errorf("video preview is disabled")
+			}
+			return handleVideoPreview(w, r, imgSvc, videoSvc, fileCache, file, previewSize, enableThumbnails, resizePreview, transform, videoFrameOffset)
+		case strings.ToLower(file.Extension) == ".pdf":
+			if !enablePdfPreview || pdfSvc == nil {
+				return http.StatusNotImplemented, fmt.Errorf("pdf preview is disabled")
+			}
+			return handlePDFPreview(w, r, imgSvc, pdfSvc, fileCache, file, previewSize, enableThumbnails, resizePreview, transform)
 		default:
 			return http.StatusNotImplemented, fmt.Errorf("can't create preview for %s type", file.Type)
 		}
@@ -69,7 +122,7 @@ func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, re
 }
 
 func handleImagePreview(w http.ResponseWriter, r *http.Request, imgSvc ImgService, fileCache FileCache,
-	file *files.FileInfo, previewSize PreviewSize, enableThumbnails, resizePreview bool) (int, error) {
+	file *files.FileInfo, previewSize PreviewSize, enableThumbnails, resizePreview, preserveExif bool, transform *previewTransform) (int, error) {
 	format, err := imgSvc.FormatFromExtension(file.Extension)
 
 	// Unsupported extensions directly return the raw data
@@ -85,7 +138,76 @@ func handleImagePreview(w http.ResponseWriter, r *http.Request, imgSvc ImgServic
 		return http.StatusNotModified, nil
 	}
 
-	cacheKey := previewCacheKey(file.Path, file.ModTime.Unix(), previewSize)
+	cacheKey := PreviewCacheKey(file.Path, file.ModTime.Unix(), previewSize, transform)
+	cachedFile, ok, err := fileCache.Load(r.Context(), cacheKey)
+	if err != nil {
+		return errToStatus(err), err
+	}
+	if ok {
+		_, _ = w.Write(cachedFile)
+		return 0, nil
+	}
+
+	resizedImage, err := createPreview(imgSvc, fileCache, file, previewSize, enableThumbnails, resizePreview, preserveExif, transform)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	_, _ = w.Write(resizedImage.Bytes())
+
+	return 0, nil
+}
+
+// handleVideoPreview extracts a still frame from the video with videoSvc and
+// then resizes it exactly like an image preview.
+func handleVideoPreview(w http.ResponseWriter, r *http.Request, imgSvc ImgService, videoSvc VideoService, fileCache FileCache,
+	file *files.FileInfo, previewSize PreviewSize, enableThumbnails, resizePreview bool, transform *previewTransform, videoFrameOffset float64) (int, error) {
+	isFresh := checkEtag(w, r, file.ModTime.Unix(), file.Size)
+	if isFresh {
+		return http.StatusNotModified, nil
+	}
+
+	cacheKey := PreviewCacheKey(file.Path, file.ModTime.Unix(), previewSize, transform)
+	cachedFile, ok, err := fileCache.Load(r.Context(), cacheKey)
+	if err != nil {
+		return errToStatus(err), err
+	}
+	if ok {
+		_, _ = w.Write(cachedFile)
+		return 0, nil
+	}
+
+	fd, err := file.Fs.Open(file.Path)
+	if err != nil {
+		return errToStatus(err), err
+	}
+	defer fd.Close()
+
+	frame := &bytes.Buffer{}
+	if err := videoSvc.Frame(r.Context(), fd, videoFrameOffset, frame); err != nil {
+		return errToStatus(err), err
+	}
+
+	resizedImage, err := createPreviewFromReader(imgSvc, fileCache, frame, file, previewSize, enableThumbnails, resizePreview, transform.options(), transform)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	_, _ = w.Write(resizedImage.Bytes())
+
+	return 0, nil
+}
+
+// handlePDFPreview rasterizes the first page of the PDF with pdfSvc and then
+// resizes it exactly like an image preview.
+func handlePDFPreview(w http.ResponseWriter, r *http.Request, imgSvc ImgService, pdfSvc PDFService, fileCache FileCache,
+	file *files.FileInfo, previewSize PreviewSize, enableThumbnails, resizePreview bool, transform *previewTransform) (int, error) {
+	isFresh := checkEtag(w, r, file.ModTime.Unix(), file.Size)
+	if isFresh {
+		return http.StatusNotModified, nil
+	}
+
+	cacheKey := PreviewCacheKey(file.Path, file.ModTime.Unix(), previewSize, transform)
 	cachedFile, ok, err := fileCache.Load(r.Context(), cacheKey)
 	if err != nil {
 		return errToStatus(err), err
@@ -95,7 +217,18 @@ func handleImagePreview(w http.ResponseWriter, r *http.Request, imgSvc ImgServic
 		return 0, nil
 	}
 
-	resizedImage, err := createPreview(imgSvc, fileCache, file, previewSize, enableThumbnails, resizePreview)
+	fd, err := file.Fs.Open(file.Path)
+	if err != nil {
+		return errToStatus(err), err
+	}
+	defer fd.Close()
+
+	page := &bytes.Buffer{}
+	if err := pdfSvc.FirstPage(r.Context(), fd, page); err != nil {
+		return errToStatus(err), err
+	}
+
+	resizedImage, err := createPreviewFromReader(imgSvc, fileCache, page, file, previewSize, enableThumbnails, resizePreview, transform.options(), transform)
 	if err != nil {
 		return errToStatus(err), err
 	}
@@ -106,13 +239,31 @@ func handleImagePreview(w http.ResponseWriter, r *http.Request, imgSvc ImgServic
 }
 
 func createPreview(imgSvc ImgService, fileCache FileCache,
-	file *files.FileInfo, previewSize PreviewSize, enableThumbnails, resizePreview bool) (*bytes.Buffer, error) {
+	file *files.FileInfo, previewSize PreviewSize, enableThumbnails, resizePreview, preserveExif bool, transform *previewTransform) (*bytes.Buffer, error) {
 	fd, err := file.Fs.Open(file.Path)
 	if err != nil {
 		return nil, err
 	}
 	defer fd.Close()
 
+	// Source images may carry an EXIF Orientation tag (portrait phone
+	// photos in particular); auto-orient before the resize computes its
+	// fit/fill geometry, and strip EXIF from the output unless the
+	// operator asked to keep it.
+	extraOptions := []img.Option{img.WithAutoOrient(true), img.WithPreserveExif(preserveExif)}
+	extraOptions = append(extraOptions, transform.options()...)
+
+	return createPreviewFromReader(imgSvc, fileCache, fd, file, previewSize, enableThumbnails, resizePreview, extraOptions, transform)
+}
+
+// createPreviewFromReader resizes in (an encoded image file, or a frame
+// extracted from a video/PDF) and stores the result in fileCache under the
+// cache key returned by PreviewCacheKey. extraOptions, if non-nil, are
+// appended to the size/quality options derived from previewSize; transform
+// is threaded through separately purely so the cache key can include it.
+func createPreviewFromReader(imgSvc ImgService, fileCache FileCache, in io.Reader,
+	file *files.FileInfo, previewSize PreviewSize, enableThumbnails, resizePreview bool,
+	extraOptions []img.Option, transform *previewTransform) (*bytes.Buffer, error) {
 	var (
 		width   int
 		height  int
@@ -131,14 +282,15 @@ func createPreview(imgSvc ImgService, fileCache FileCache,
 	default:
 		return nil, img.ErrUnsupportedFormat
 	}
+	options = append(options, extraOptions...)
 
 	buf := &bytes.Buffer{}
-	if err := imgSvc.Resize(context.Background(), fd, width, height, buf, options...); err != nil {
+	if err := imgSvc.Resize(context.Background(), in, width, height, buf, options...); err != nil {
 		return nil, err
 	}
 
 	go func() {
-		cacheKey := previewCacheKey(file.Path, file.ModTime.Unix(), previewSize)
+		cacheKey := PreviewCacheKey(file.Path, file.ModTime.Unix(), previewSize, transform)
 		if err := fileCache.Store(context.Background(), cacheKey, buf.Bytes()); err != nil {
 			fmt.Printf("failed to cache resized image: %v", err)
 		}
@@ -147,6 +299,12 @@ func createPreview(imgSvc ImgService, fileCache FileCache,
 	return buf, nil
 }
 
-func previewCacheKey(fPath string, fTime int64, previewSize PreviewSize) string {
-	return fmt.Sprintf("%x%x%x", fPath, fTime, previewSize)
+// PreviewCacheKey identifies a cached preview by path, mtime, size and any
+// crop/rotate transform, so each transform combination is cached
+// independently instead of colliding with (or evicting) the plain preview.
+// Exported so cmd's thumbnails generate command can pre-populate the cache
+// under the exact key the preview handler will look up, instead of
+// reimplementing the format.
+func PreviewCacheKey(fPath string, fTime int64, previewSize PreviewSize, transform *previewTransform) string {
+	return fmt.Sprintf("%x%x%x%s", fPath, fTime, previewSize, transform.cacheSuffix())
 }
@@ -0,0 +1,207 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/robinliucn/filebrowser/v2/settings"
+	"github.com/robinliucn/filebrowser/v2/storage"
+)
+
+// previewTokenTTLMax caps how far in the future a minted token's expiry can
+// be set, so a leaked URL can't be replayed forever.
+const previewTokenTTLMax = 7 * 24 * time.Hour
+
+// previewTokenSecret returns the server-side HMAC key used to mint and
+// verify preview tokens, persisted in settings.Storage so it survives
+// restarts and is shared across replicas.
+func previewTokenSecret(st *storage.Storage) ([]byte, error) {
+	return st.Settings.GetOrGeneratePreviewTokenSecret()
+}
+
+// signPreviewToken computes the token travelling in a signed preview URL.
+// The HMAC covers path, size, expiry and userID, so a token minted for one
+// file/size/user combination can't be replayed against another.
+func signPreviewToken(secret []byte, path string, size PreviewSize, expiry time.Time, userID uint) string {
+	mac := previewTokenMAC(secret, path, size, expiry.Unix(), userID)
+	payload := fmt.Sprintf("%d.%d.%s", expiry.Unix(), userID, hex.EncodeToString(mac))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload))
+}
+
+// verifyPreviewToken decodes token and checks its HMAC and expiry against
+// path and size. On success it returns the userID the token was minted
+// for, so the caller can look up that user's permissions.
+func verifyPreviewToken(secret []byte, token, path string, size PreviewSize) (uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("preview token: malformed encoding: %w", err)
+	}
+
+	parts := strings.SplitN(string(raw), ".", 3)
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("preview token: malformed payload")
+	}
+
+	expiryUnix, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("preview token: malformed expiry: %w", err)
+	}
+	userID64, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("preview token: malformed userID: %w", err)
+	}
+	userID := uint(userID64)
+
+	want := previewTokenMAC(secret, path, size, expiryUnix, userID)
+	got, err := hex.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(want, got) {
+		return 0, fmt.Errorf("preview token: signature mismatch")
+	}
+
+	if time.Now().Unix() > expiryUnix {
+		return 0, fmt.Errorf("preview token: expired")
+	}
+
+	return userID, nil
+}
+
+func previewTokenMAC(secret []byte, path string, size PreviewSize, expiryUnix int64, userID uint) []byte {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%s|%s|%d|%d", path, size, expiryUnix, userID)
+	return mac.Sum(nil)
+}
+
+// tokenPreviewHandler serves GET /api/preview/{size}/{path}?token=... . It
+// validates the token instead of requiring a session cookie, so previews
+// can be embedded in third-party pages (chat, wikis, RSS readers) without a
+// filebrowser login. Perm.Download is still checked, against the user the
+// token was minted for. On any mismatch it renders a small SVG placeholder
+// with 403 so <img> tags degrade gracefully instead of showing a
+// broken-image icon.
+func tokenPreviewHandler(srv *settings.Server, st *storage.Storage, imgSvc ImgService, videoSvc VideoService,
+	pdfSvc PDFService, fileCache FileCache, enableThumbnails, resizePreview, enableVideoPreview, enablePdfPreview, preserveExif bool, videoFrameOffset float64) http.Handler {
+	inner := previewHandler(imgSvc, videoSvc, pdfSvc, fileCache,
+		enableThumbnails, resizePreview, enableVideoPreview, enablePdfPreview, preserveExif, videoFrameOffset)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d, status, err := buildTokenPreviewData(r, srv, st)
+		if err != nil {
+			renderPreviewTokenError(w, status)
+			return
+		}
+
+		if !d.user.Perm.Download {
+			renderPreviewTokenError(w, http.StatusForbidden)
+			return
+		}
+
+		if status, err := inner(w, r, d); err != nil {
+			renderPreviewTokenError(w, status)
+		}
+	})
+}
+
+// buildTokenPreviewData validates the token query param against the
+// request's size/path and resolves the user it was minted for, without
+// relying on the session cookie withUser reads.
+func buildTokenPreviewData(r *http.Request, srv *settings.Server, st *storage.Storage) (*data, int, error) {
+	vars := mux.Vars(r)
+	previewSize, err := ParsePreviewSize(vars["size"])
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		return nil, http.StatusForbidden, fmt.Errorf("preview token: missing token")
+	}
+
+	secret, err := previewTokenSecret(st)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	path := "/" + vars["path"]
+	userID, err := verifyPreviewToken(secret, token, path, previewSize)
+	if err != nil {
+		return nil, http.StatusForbidden, err
+	}
+
+	user, err := st.Users.Get(srv.Root, userID)
+	if err != nil {
+		return nil, http.StatusForbidden, err
+	}
+
+	return &data{server: srv, store: st, user: user}, 0, nil
+}
+
+// renderPreviewTokenError writes a tiny neutral placeholder image instead of
+// a JSON error body, so an <img> tag pointed at an expired/invalid preview
+// URL still renders something instead of a broken-image icon.
+func renderPreviewTokenError(w http.ResponseWriter, status int) {
+	if status == 0 {
+		status = http.StatusForbidden
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg" width="128" height="128">` +
+		`<rect width="100%" height="100%" fill="#eee"/>` +
+		`<text x="50%" y="50%" dominant-baseline="middle" text-anchor="middle" fill="#999" font-size="12">preview unavailable</text>` +
+		`</svg>`))
+}
+
+// mintPreviewTokenHandler serves POST /api/share/preview-token for an
+// authenticated user, returning a URL usable without a session cookie for
+// up to ttlSeconds (capped at previewTokenTTLMax).
+func mintPreviewTokenHandler() handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if !d.user.Perm.Download {
+			return http.StatusForbidden, nil
+		}
+
+		var body struct {
+			Path       string `json:"path"`
+			Size       string `json:"size"`
+			TTLSeconds int64  `json:"ttlSeconds"`
+		}
+		if err := parseJSON(r, &body); err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		previewSize, err := ParsePreviewSize(body.Size)
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		ttl := time.Duration(body.TTLSeconds) * time.Second
+		if ttl <= 0 || ttl > previewTokenTTLMax {
+			ttl = previewTokenTTLMax
+		}
+		expiry := time.Now().Add(ttl)
+
+		secret, err := previewTokenSecret(d.store)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		token := signPreviewToken(secret, body.Path, previewSize, expiry, d.user.ID)
+
+		return renderJSON(w, r, &struct {
+			URL       string    `json:"url"`
+			ExpiresAt time.Time `json:"expiresAt"`
+		}{
+			URL:       fmt.Sprintf("/api/preview/%s%s?token=%s", body.Size, body.Path, token),
+			ExpiresAt: expiry,
+		})
+	})
+}
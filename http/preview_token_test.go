@@ -0,0 +1,79 @@
+package http
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignAndVerifyPreviewToken(t *testing.T) {
+	secret := []byte("test-secret")
+	path := "/photos/beach.jpg"
+	size := PreviewSizeBig
+	expiry := time.Now().Add(time.Hour)
+
+	token := signPreviewToken(secret, path, size, expiry, 42)
+
+	userID, err := verifyPreviewToken(secret, token, path, size)
+	if err != nil {
+		t.Fatalf("verifyPreviewToken: %v", err)
+	}
+	if userID != 42 {
+		t.Errorf("userID = %d, want 42", userID)
+	}
+}
+
+func TestVerifyPreviewTokenExpired(t *testing.T) {
+	secret := []byte("test-secret")
+	path := "/photos/beach.jpg"
+	size := PreviewSizeBig
+	expiry := time.Now().Add(-time.Minute)
+
+	token := signPreviewToken(secret, path, size, expiry, 42)
+
+	if _, err := verifyPreviewToken(secret, token, path, size); err == nil {
+		t.Fatal("verifyPreviewToken: expected error for expired token, got nil")
+	}
+}
+
+func TestVerifyPreviewTokenWrongSecret(t *testing.T) {
+	path := "/photos/beach.jpg"
+	size := PreviewSizeBig
+	expiry := time.Now().Add(time.Hour)
+
+	token := signPreviewToken([]byte("secret-a"), path, size, expiry, 42)
+
+	if _, err := verifyPreviewToken([]byte("secret-b"), token, path, size); err == nil {
+		t.Fatal("verifyPreviewToken: expected error for mismatched secret, got nil")
+	}
+}
+
+func TestVerifyPreviewTokenPathMismatch(t *testing.T) {
+	secret := []byte("test-secret")
+	size := PreviewSizeBig
+	expiry := time.Now().Add(time.Hour)
+
+	token := signPreviewToken(secret, "/photos/beach.jpg", size, expiry, 42)
+
+	if _, err := verifyPreviewToken(secret, token, "/photos/other.jpg", size); err == nil {
+		t.Fatal("verifyPreviewToken: expected error for mismatched path, got nil")
+	}
+}
+
+func TestVerifyPreviewTokenSizeMismatch(t *testing.T) {
+	secret := []byte("test-secret")
+	path := "/photos/beach.jpg"
+	expiry := time.Now().Add(time.Hour)
+
+	token := signPreviewToken(secret, path, PreviewSizeBig, expiry, 42)
+
+	if _, err := verifyPreviewToken(secret, token, path, PreviewSizeThumb); err == nil {
+		t.Fatal("verifyPreviewToken: expected error for mismatched size, got nil")
+	}
+}
+
+func TestVerifyPreviewTokenMalformed(t *testing.T) {
+	secret := []byte("test-secret")
+	if _, err := verifyPreviewToken(secret, "not-valid-base64!!", "/x.jpg", PreviewSizeBig); err == nil {
+		t.Fatal("verifyPreviewToken: expected error for malformed token, got nil")
+	}
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/robinliucn/filebrowser/v2/img"
+)
+
+// previewTransform carries the optional ?crop=x,y,w,h&rotate=deg query
+// params a request can layer on top of a plain thumb/big preview, so the
+// frontend can offer a lightweight crop/rotate editor without downloading
+// the original.
+type previewTransform struct {
+	crop   *cropRect
+	rotate int
+}
+
+type cropRect struct {
+	X, Y, W, H int
+}
+
+// parsePreviewTransform reads crop/rotate from the query string. Both are
+// optional; an empty result means "no transform".
+func parsePreviewTransform(r *http.Request) (*previewTransform, error) {
+	q := r.URL.Query()
+	cropParam := q.Get("crop")
+	rotateParam := q.Get("rotate")
+	if cropParam == "" && rotateParam == "" {
+		return nil, nil
+	}
+
+	t := &previewTransform{}
+
+	if cropParam != "" {
+		parts := strings.Split(cropParam, ",")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("crop must be x,y,w,h")
+		}
+		vals := make([]int, 4)
+		for i, p := range parts {
+			v, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil {
+				return nil, fmt.Errorf("crop must be x,y,w,h: %w", err)
+			}
+			vals[i] = v
+		}
+		if vals[2] <= 0 || vals[3] <= 0 {
+			return nil, fmt.Errorf("crop width/height must be positive")
+		}
+		t.crop = &cropRect{X: vals[0], Y: vals[1], W: vals[2], H: vals[3]}
+	}
+
+	if rotateParam != "" {
+		deg, err := strconv.Atoi(rotateParam)
+		if err != nil || deg%90 != 0 {
+			return nil, fmt.Errorf("rotate must be a multiple of 90")
+		}
+		t.rotate = ((deg % 360) + 360) % 360
+	}
+
+	return t, nil
+}
+
+// options turns the transform into img.Options. createPreviewFromReader
+// appends them after its size/quality options; Resize applies the crop and
+// rotate after auto-orient and before scaling.
+func (t *previewTransform) options() []img.Option {
+	if t == nil {
+		return nil
+	}
+	var opts []img.Option
+	if t.crop != nil {
+		opts = append(opts, img.WithCrop(t.crop.X, t.crop.Y, t.crop.W, t.crop.H))
+	}
+	if t.rotate != 0 {
+		opts = append(opts, img.WithRotate(t.rotate))
+	}
+	return opts
+}
+
+// cacheSuffix is appended to the plain path+mtime+size cache key so each
+// crop/rotate combination gets its own independently cached variant instead
+// of colliding with (or evicting) the unmodified preview.
+func (t *previewTransform) cacheSuffix() string {
+	if t == nil {
+		return ""
+	}
+	suffix := fmt.Sprintf("r%d", t.rotate)
+	if t.crop != nil {
+		suffix += fmt.Sprintf("c%d.%d.%d.%d", t.crop.X, t.crop.Y, t.crop.W, t.crop.H)
+	}
+	return suffix
+}
@@ -0,0 +1,31 @@
+package img
+
+import "image"
+
+// WithCrop restricts the resize to the given region of the source image,
+// applied after auto-orient and before WithRotate.
+func WithCrop(x, y, w, h int) Option {
+	return func(o *Options) { o.Crop = &Rect{X: x, Y: y, W: w, H: h} }
+}
+
+// WithRotate rotates the image clockwise by deg degrees (must be a multiple
+// of 90 once normalized to [0, 360) by the caller), applied after WithCrop
+// and before the resize.
+func WithRotate(deg int) Option {
+	return func(o *Options) { o.Rotate = deg }
+}
+
+// rotateImage rotates src clockwise by deg degrees, which must already be
+// normalized to one of 90, 180 or 270 by the caller.
+func rotateImage(src image.Image, deg int) image.Image {
+	switch ((deg % 360) + 360) % 360 {
+	case 90:
+		return rotate90(src)
+	case 180:
+		return rotate90(rotate90(src))
+	case 270:
+		return rotate90(rotate90(rotate90(src)))
+	default:
+		return src
+	}
+}
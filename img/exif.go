@@ -0,0 +1,153 @@
+package img
+
+import (
+	"encoding/binary"
+	"image"
+)
+
+// exifOrientationTag is the TIFF tag ID for the Orientation field inside a
+// JPEG's APP1/Exif segment.
+const exifOrientationTag = 0x0112
+
+// readOrientation scans raw (an undecoded JPEG file) for an EXIF
+// Orientation tag and returns its value (1-8), or 1 (no transform needed)
+// if none is found or raw isn't a JPEG with a parseable Exif segment.
+func readOrientation(raw []byte) int {
+	segment := findAPP1Segment(raw)
+	if segment == nil || len(segment) < 10 {
+		return 1
+	}
+	if o := parseExifOrientation(segment[10:]); o > 0 {
+		return o
+	}
+	return 1
+}
+
+// findAPP1Segment scans raw (an undecoded JPEG file) for its Exif APP1
+// segment and returns the segment verbatim, including the 0xFFE1 marker
+// and length bytes, so it can be spliced back into a re-encoded JPEG (see
+// encodeJpegWithAPP1 in img.go). Returns nil if raw isn't a JPEG or
+// carries no Exif APP1 segment.
+func findAPP1Segment(raw []byte) []byte {
+	if len(raw) < 4 || raw[0] != 0xFF || raw[1] != 0xD8 {
+		return nil
+	}
+
+	pos := 2
+	for pos+4 <= len(raw) {
+		if raw[pos] != 0xFF {
+			break
+		}
+		marker := raw[pos+1]
+		if marker == 0xD8 || marker == 0xD9 {
+			pos += 2
+			continue
+		}
+		segLen := int(binary.BigEndian.Uint16(raw[pos+2 : pos+4]))
+		segStart := pos + 4
+		segEnd := pos + 2 + segLen
+		if segEnd > len(raw) {
+			break
+		}
+
+		if marker == 0xE1 && segEnd-segStart >= 6 && string(raw[segStart:segStart+6]) == "Exif\x00\x00" {
+			return raw[pos:segEnd]
+		}
+
+		// SOS marker: the rest of the file is compressed scan data, no
+		// more APPn markers to find.
+		if marker == 0xDA {
+			break
+		}
+		pos = segEnd
+	}
+	return nil
+}
+
+// parseExifOrientation reads the Orientation tag out of a TIFF-structured
+// Exif blob (the bytes immediately after the "Exif\0\0" header).
+func parseExifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifdOffset := order.Uint32(tiff[4:8])
+	if int(ifdOffset)+2 > len(tiff) {
+		return 0
+	}
+
+	numEntries := int(order.Uint16(tiff[ifdOffset : ifdOffset+2]))
+	entriesStart := int(ifdOffset) + 2
+	for i := 0; i < numEntries; i++ {
+		entryStart := entriesStart + i*12
+		if entryStart+12 > len(tiff) {
+			break
+		}
+		tag := order.Uint16(tiff[entryStart : entryStart+2])
+		if tag != exifOrientationTag {
+			continue
+		}
+		value := order.Uint16(tiff[entryStart+8 : entryStart+10])
+		if value < 1 || value > 8 {
+			return 0
+		}
+		return int(value)
+	}
+	return 0
+}
+
+// applyOrientation rotates/flips img according to the EXIF Orientation
+// value (1-8; see the EXIF 2.32 spec, tag 0x0112) so the output matches
+// how the image is meant to be displayed.
+func applyOrientation(src image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return flipH(src)
+	case 3:
+		return rotate90(rotate90(src))
+	case 4:
+		return flipV(src)
+	case 5:
+		return flipH(rotate90(src))
+	case 6:
+		return rotate90(src)
+	case 7:
+		return flipH(rotate90(rotate90(rotate90(src))))
+	case 8:
+		return rotate90(rotate90(rotate90(src)))
+	default:
+		return src
+	}
+}
+
+func flipH(src image.Image) image.Image {
+	b := src.Bounds()
+	dst := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dst.Set(b.Max.X-1-(x-b.Min.X), y, src.At(x, y))
+		}
+	}
+	return dst
+}
+
+func flipV(src image.Image) image.Image {
+	b := src.Bounds()
+	dst := image.NewRGBA(b)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dst.Set(x, b.Max.Y-1-(y-b.Min.Y), src.At(x, y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,78 @@
+package img
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// asymmetricImage returns a 2x3 image where every pixel is distinct, so any
+// mistaken flip/rotate/transpose produces a detectably different result.
+func asymmetricImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 3))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	img.Set(1, 0, color.RGBA{0, 255, 0, 255})
+	img.Set(0, 1, color.RGBA{0, 0, 255, 255})
+	img.Set(1, 1, color.RGBA{255, 255, 0, 255})
+	img.Set(0, 2, color.RGBA{255, 0, 255, 255})
+	img.Set(1, 2, color.RGBA{0, 255, 255, 255})
+	return img
+}
+
+func pixelsEqual(a, b image.Image) bool {
+	ab, bb := a.Bounds(), b.Bounds()
+	if ab.Dx() != bb.Dx() || ab.Dy() != bb.Dy() {
+		return false
+	}
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			ar, ag, abb, aa := a.At(ab.Min.X+x, ab.Min.Y+y).RGBA()
+			br, bg, bbb, ba := b.At(bb.Min.X+x, bb.Min.Y+y).RGBA()
+			if ar != br || ag != bg || abb != bbb || aa != ba {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestApplyOrientation(t *testing.T) {
+	src := asymmetricImage()
+
+	// Reference transforms for each EXIF orientation value, composed from
+	// the primitive flipH/flipV/rotate90. Orientation 7 is composed
+	// differently from applyOrientation (rotate then flip vertically, rather
+	// than rotate three times then flip horizontally), so that case checks
+	// two equivalent derivations against each other; the other cases repeat
+	// applyOrientation's own expressions.
+	cases := []struct {
+		orientation int
+		want        image.Image
+	}{
+		{1, src},
+		{2, flipH(src)},
+		{3, rotate90(rotate90(src))},
+		{4, flipV(src)},
+		{5, flipH(rotate90(src))},
+		{6, rotate90(src)},
+		{7, flipV(rotate90(src))},
+		{8, rotate90(rotate90(rotate90(src)))},
+	}
+
+	for _, c := range cases {
+		got := applyOrientation(src, c.orientation)
+		if !pixelsEqual(got, c.want) {
+			t.Errorf("applyOrientation(orientation=%d): pixels don't match expected transform", c.orientation)
+		}
+	}
+}
+
+func TestApplyOrientationDistinctFrom57(t *testing.T) {
+	src := asymmetricImage()
+
+	o5 := applyOrientation(src, 5)
+	o7 := applyOrientation(src, 7)
+	if pixelsEqual(o5, o7) {
+		t.Fatal("orientation 5 (transpose) and 7 (transverse) produced the same result on an asymmetric image")
+	}
+}
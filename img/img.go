@@ -0,0 +1,227 @@
+// Package img resizes images for the preview pipeline. It decodes a source
+// image, optionally auto-orients it from EXIF, scales it to fit/fill the
+// requested bounds, and re-encodes it.
+package img
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned by FormatFromExtension for an extension
+// the preview pipeline doesn't know how to decode, and by Resize if asked
+// to encode to one.
+var ErrUnsupportedFormat = errors.New("img: unsupported format")
+
+// Format identifies an image codec.
+type Format int
+
+const (
+	FormatJpeg Format = iota
+	FormatPng
+	FormatGif
+)
+
+// ResizeMode controls how the source image is fit into the requested
+// width/height.
+type ResizeMode int
+
+const (
+	// ResizeModeFit scales the image down to fit entirely within the
+	// requested bounds, preserving aspect ratio; the result may be smaller
+	// than width/height on one axis.
+	ResizeModeFit ResizeMode = iota
+	// ResizeModeFill scales and crops so the result exactly fills the
+	// requested bounds.
+	ResizeModeFill
+)
+
+// Quality controls the JPEG encoder quality level used for the output.
+type Quality int
+
+const (
+	QualityLow Quality = iota
+	QualityMedium
+	QualityHigh
+)
+
+func (q Quality) jpegQuality() int {
+	switch q {
+	case QualityLow:
+		return 60
+	case QualityHigh:
+		return 95
+	default:
+		return 80
+	}
+}
+
+// Options holds the resolved configuration for a single Resize call, built
+// up from the Option values passed to it.
+type Options struct {
+	Mode         ResizeMode
+	Quality      Quality
+	Format       Format
+	FormatSet    bool
+	AutoOrient   bool
+	PreserveExif bool
+	Crop         *Rect
+	Rotate       int
+}
+
+// Option configures a single call to Service.Resize.
+type Option func(*Options)
+
+// WithMode sets how the source image is fit into the requested bounds.
+func WithMode(mode ResizeMode) Option {
+	return func(o *Options) { o.Mode = mode }
+}
+
+// WithQuality sets the JPEG encoder quality level for the output.
+func WithQuality(q Quality) Option {
+	return func(o *Options) { o.Quality = q }
+}
+
+// WithFormat forces the output format regardless of the source format.
+func WithFormat(f Format) Option {
+	return func(o *Options) { o.Format = f; o.FormatSet = true }
+}
+
+// WithAutoOrient rotates/flips the decoded image according to its EXIF
+// Orientation tag (if any) before any crop, rotate or resize is applied.
+func WithAutoOrient(v bool) Option {
+	return func(o *Options) { o.AutoOrient = v }
+}
+
+// WithPreserveExif controls whether the output keeps the source's EXIF
+// metadata. When true and the source and output are both JPEG, the
+// source's Exif APP1 segment (orientation included) is spliced back into
+// the re-encoded output, since image/jpeg otherwise drops it. Other source
+// formats don't carry Exif the pipeline understands, so the flag is a
+// no-op for them.
+func WithPreserveExif(v bool) Option {
+	return func(o *Options) { o.PreserveExif = v }
+}
+
+// Service decodes, transforms and re-encodes images.
+type Service struct{}
+
+// New returns a ready to use Service.
+func New() *Service {
+	return &Service{}
+}
+
+// FormatFromExtension maps a file extension (as returned by
+// files.FileInfo.Extension, leading dot included) to a Format.
+func (s *Service) FormatFromExtension(ext string) (Format, error) {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg":
+		return FormatJpeg, nil
+	case ".png":
+		return FormatPng, nil
+	case ".gif":
+		return FormatGif, nil
+	default:
+		return 0, ErrUnsupportedFormat
+	}
+}
+
+// Resize decodes in, applies the given options, scales the result to
+// width x height, and writes the encoded output to out. A width or height
+// of 0 is replaced by the image's own size on that axis before the scale
+// is computed; with both 0 (as the transform endpoint, which only crops or
+// rotates, passes) the image keeps its native size.
+func (s *Service) Resize(_ context.Context, in io.Reader, width, height int, out io.Writer, options ...Option) error {
+	var o Options
+	for _, opt := range options {
+		opt(&o)
+	}
+
+	raw, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	srcImg, srcFormat, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("img: decode failed: %w", err)
+	}
+
+	if o.AutoOrient {
+		if orientation := readOrientation(raw); orientation > 1 {
+			srcImg = applyOrientation(srcImg, orientation)
+		}
+	}
+
+	if o.Crop != nil {
+		srcImg = cropImage(srcImg, *o.Crop)
+	}
+
+	if o.Rotate != 0 {
+		srcImg = rotateImage(srcImg, o.Rotate)
+	}
+
+	if width > 0 || height > 0 {
+		srcImg = resizeImage(srcImg, width, height, o.Mode)
+	}
+
+	format := o.Format
+	if !o.FormatSet {
+		switch srcFormat {
+		case "png":
+			format = FormatPng
+		case "gif":
+			format = FormatGif
+		default:
+			format = FormatJpeg
+		}
+	}
+
+	switch format {
+	case FormatPng:
+		return png.Encode(out, srcImg)
+	case FormatGif:
+		return gif.Encode(out, srcImg, nil)
+	case FormatJpeg:
+		if o.PreserveExif {
+			if app1 := findAPP1Segment(raw); app1 != nil {
+				return encodeJpegWithAPP1(out, srcImg, o.Quality, app1)
+			}
+		}
+		return jpeg.Encode(out, srcImg, &jpeg.Options{Quality: o.Quality.jpegQuality()})
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// encodeJpegWithAPP1 encodes srcImg as a JPEG and splices app1 (the raw
+// Exif APP1 segment bytes captured from the source file, markers and all)
+// in right after the SOI marker, so the output keeps the source's EXIF
+// metadata instead of the image/jpeg encoder dropping it.
+func encodeJpegWithAPP1(out io.Writer, srcImg image.Image, quality Quality, app1 []byte) error {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, srcImg, &jpeg.Options{Quality: quality.jpegQuality()}); err != nil {
+		return err
+	}
+	encoded := buf.Bytes()
+	if len(encoded) < 2 {
+		return fmt.Errorf("img: jpeg encode produced a truncated file")
+	}
+
+	if _, err := out.Write(encoded[:2]); err != nil { // SOI
+		return err
+	}
+	if _, err := out.Write(app1); err != nil {
+		return err
+	}
+	_, err := out.Write(encoded[2:])
+	return err
+}
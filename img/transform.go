@@ -0,0 +1,136 @@
+package img
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Rect is a region in source-image pixel coordinates.
+type Rect struct {
+	X, Y, W, H int
+}
+
+// cropImage returns the sub-image of src described by r, clamped to src's
+// bounds so an out-of-range crop (e.g. from a stale client-side selection)
+// doesn't panic.
+func cropImage(src image.Image, r Rect) image.Image {
+	bounds := src.Bounds()
+	rect := image.Rect(r.X, r.Y, r.X+r.W, r.Y+r.H).Add(bounds.Min).Intersect(bounds)
+	if rect.Empty() {
+		return src
+	}
+
+	type subImager interface {
+		SubImage(r image.Rectangle) image.Image
+	}
+	if si, ok := src.(subImager); ok {
+		return si.SubImage(rect)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, rect.Min, draw.Src)
+	return dst
+}
+
+// rotate90 rotates src 90 degrees clockwise.
+func rotate90(src image.Image) image.Image {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dy(), b.Dx()))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dst.Set(b.Max.Y-1-y, x-b.Min.X, src.At(x, y))
+		}
+	}
+	return dst
+}
+
+// resizeImage scales src so it fits or fills width x height depending on
+// mode. A width or height of 0 is replaced by src's own size on that axis
+// before the scale is computed, so in fit mode only the other axis limits
+// the aspect-preserving scale; with both 0 the image keeps its native size.
+func resizeImage(src image.Image, width, height int, mode ResizeMode) image.Image {
+	b := src.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	if srcW == 0 || srcH == 0 {
+		return src
+	}
+	if width <= 0 {
+		width = srcW
+	}
+	if height <= 0 {
+		height = srcH
+	}
+
+	scaleX := float64(width) / float64(srcW)
+	scaleY := float64(height) / float64(srcH)
+
+	var scale float64
+	switch mode {
+	case ResizeModeFill:
+		scale = maxFloat(scaleX, scaleY)
+	default: // ResizeModeFit
+		scale = minFloat(scaleX, scaleY)
+	}
+	if scale >= 1 {
+		// Never upscale; a thumbnail request for an image already smaller
+		// than the target keeps its native size.
+		return src
+	}
+
+	dstW := int(float64(srcW) * scale)
+	dstH := int(float64(srcH) * scale)
+	if dstW < 1 {
+		dstW = 1
+	}
+	if dstH < 1 {
+		dstH = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		srcY := b.Min.Y + y*srcH/dstH
+		for x := 0; x < dstW; x++ {
+			srcX := b.Min.X + x*srcW/dstW
+			dst.Set(x, y, src.At(srcX, srcY))
+		}
+	}
+
+	if mode == ResizeModeFill && (dstW != width || dstH != height) {
+		return cropImage(dst, Rect{
+			X: maxInt(0, (dstW-width)/2),
+			Y: maxInt(0, (dstH-height)/2),
+			W: minInt(dstW, width),
+			H: minInt(dstH, height),
+		})
+	}
+
+	return dst
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
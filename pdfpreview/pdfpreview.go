@@ -0,0 +1,67 @@
+// Package pdfpreview provides a PDFService implementation that rasterizes
+// the first page of a PDF document using the pdftoppm CLI (part of poppler-utils).
+package pdfpreview
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// ErrPdftoppmNotFound is returned when the pdftoppm binary can't be located
+// on PATH, so callers can disable PDF previews instead of failing requests.
+var ErrPdftoppmNotFound = errors.New("pdftoppm binary not found")
+
+// Service rasterizes PDF pages using the pdftoppm CLI.
+type Service struct {
+	pdftoppmPath string
+}
+
+// NewService locates pdftoppm on PATH and returns a ready to use Service. It
+// returns ErrPdftoppmNotFound if pdftoppm isn't installed, so operators
+// without it can still run filebrowser with PDF previews disabled.
+func NewService() (*Service, error) {
+	path, err := exec.LookPath("pdftoppm")
+	if err != nil {
+		return nil, ErrPdftoppmNotFound
+	}
+	return &Service{pdftoppmPath: path}, nil
+}
+
+// FirstPage writes a JPEG rendering of the first page of the PDF read from
+// in to out.
+func (s *Service) FirstPage(ctx context.Context, in io.Reader, out io.Writer) error {
+	tmp, err := os.CreateTemp("", "filebrowser-pdf-*.pdf")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	// #nosec G204 -- arguments are built from a fixed template, not user input
+	cmd := exec.CommandContext(ctx, s.pdftoppmPath,
+		"-jpeg",
+		"-f", "1",
+		"-l", "1",
+		"-singlefile",
+		tmp.Name(),
+		"-",
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pdfpreview: pdftoppm failed: %w: %s", err, stderr.String())
+	}
+
+	_, err = out.Write(stdout.Bytes())
+	return err
+}
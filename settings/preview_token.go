@@ -0,0 +1,46 @@
+package settings
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var secretsBucket = []byte("secrets")
+
+const previewTokenSecretKey = "preview_token_secret"
+
+// previewTokenSecretLen is 256 bits, matching the HMAC-SHA256 key size the
+// preview token signer uses.
+const previewTokenSecretLen = 32
+
+// GetOrGeneratePreviewTokenSecret returns the server-side HMAC key used to
+// mint and verify preview tokens. The first call generates and persists a
+// random key; later calls (including from other replicas sharing this
+// Storage) return the same one, so a token minted by one server instance
+// verifies on another.
+func (s *Storage) GetOrGeneratePreviewTokenSecret() ([]byte, error) {
+	var secret []byte
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(secretsBucket)
+		if err != nil {
+			return err
+		}
+		if v := bucket.Get([]byte(previewTokenSecretKey)); v != nil {
+			secret = append([]byte(nil), v...)
+			return nil
+		}
+
+		generated := make([]byte, previewTokenSecretLen)
+		if _, err := rand.Read(generated); err != nil {
+			return fmt.Errorf("settings: generating preview token secret: %w", err)
+		}
+		if err := bucket.Put([]byte(previewTokenSecretKey), generated); err != nil {
+			return err
+		}
+		secret = generated
+		return nil
+	})
+	return secret, err
+}
@@ -0,0 +1,15 @@
+package settings
+
+// Server holds the server-wide configuration read on every request, as
+// opposed to Storage, which holds configuration persisted to disk.
+type Server struct {
+	// Root is the default scope new users are rooted at.
+	Root string
+	// TypeDetectionByHeader sniffs a file's content type from its header
+	// instead of trusting its extension.
+	TypeDetectionByHeader bool
+	// VideoFrameOffset is how far into a video the preview pipeline grabs
+	// its still frame, expressed as a fraction (0.0-1.0) of the video's
+	// duration. Zero means "use the pipeline's default".
+	VideoFrameOffset float64
+}
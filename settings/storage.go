@@ -0,0 +1,52 @@
+// Package settings persists server-wide configuration that isn't tied to a
+// single user, such as Auther method configs and server-generated secrets.
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var autherConfigBucket = []byte("auther_config")
+
+// Storage persists settings in a BoltDB bucket per concern, the same
+// pattern filecache.DiskCache uses for its index.
+type Storage struct {
+	db *bolt.DB
+}
+
+// NewStorage returns a ready to use Storage backed by db, creating the
+// buckets it needs if this is the first run.
+func NewStorage(db *bolt.DB) (*Storage, error) {
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(autherConfigBucket)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("settings: init failed: %w", err)
+	}
+	return &Storage{db: db}, nil
+}
+
+// GetAutherConfig returns the raw JSON config blob persisted for the named
+// auth method (e.g. "oidc", "ldap"), or nil if none has been set.
+func (s *Storage) GetAutherConfig(method string) (json.RawMessage, error) {
+	var raw json.RawMessage
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(autherConfigBucket).Get([]byte(method))
+		if v != nil {
+			raw = append(json.RawMessage(nil), v...)
+		}
+		return nil
+	})
+	return raw, err
+}
+
+// SetAutherConfig persists the raw JSON config blob for the named auth
+// method, overwriting whatever was there before.
+func (s *Storage) SetAutherConfig(method string, raw json.RawMessage) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(autherConfigBucket).Put([]byte(method), raw)
+	})
+}
@@ -1,6 +1,8 @@
 package storage
 
 import (
+	"encoding/json"
+
 	"github.com/robinliucn/filebrowser/v2/auth"
 	"github.com/robinliucn/filebrowser/v2/settings"
 	"github.com/robinliucn/filebrowser/v2/share"
@@ -15,3 +17,18 @@ type Storage struct {
 	Auth     *auth.Storage
 	Settings *settings.Storage
 }
+
+// AutherConfig returns the raw JSON config blob persisted for the named
+// auth method (e.g. "oidc", "ldap"), as set by SetAutherConfig. Auth
+// methods are no longer restricted to the fixed set of fields Settings used
+// to carry directly; any method registered via auth.Register can persist
+// its own config shape here.
+func (s *Storage) AutherConfig(method string) (json.RawMessage, error) {
+	return s.Settings.GetAutherConfig(method)
+}
+
+// SetAutherConfig persists the raw JSON config blob for the named auth
+// method.
+func (s *Storage) SetAutherConfig(method string, raw json.RawMessage) error {
+	return s.Settings.SetAutherConfig(method, raw)
+}
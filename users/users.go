@@ -0,0 +1,50 @@
+// Package users defines the user model and the store interface auth and
+// http use to look up and persist users.
+package users
+
+import (
+	"errors"
+
+	"github.com/spf13/afero"
+)
+
+// ErrNotExist is returned by Store.Get when no user matches the given
+// scope/id. Callers that need to tell a genuine "no such user" apart from
+// a transient store error (e.g. to decide whether to auto-provision) should
+// check for this with errors.Is rather than treating any error as not-found.
+var ErrNotExist = errors.New("users: user does not exist")
+
+// Permissions is the set of actions a user is allowed to perform.
+type Permissions struct {
+	Admin    bool `json:"admin"`
+	Execute  bool `json:"execute"`
+	Create   bool `json:"create"`
+	Rename   bool `json:"rename"`
+	Modify   bool `json:"modify"`
+	Delete   bool `json:"delete"`
+	Share    bool `json:"share"`
+	Download bool `json:"download"`
+	// Preview allows generating crop/rotate previews of a file the user
+	// can't otherwise Modify, since a preview never touches the original.
+	// Persisting an edit through the transform endpoint still requires
+	// Modify.
+	Preview bool `json:"preview"`
+}
+
+// User is a filebrowser account.
+type User struct {
+	ID       uint        `json:"id"`
+	Username string      `json:"username"`
+	Password string      `json:"password"`
+	Scope    string      `json:"scope"`
+	Perm     Permissions `json:"perm"`
+	Fs       afero.Fs    `json:"-"`
+}
+
+// Store persists and looks up users. id is either a username (string) or a
+// user ID (uint), mirroring how callers already have one or the other on
+// hand (a login form has a username, a preview token has an ID).
+type Store interface {
+	Get(scope string, id interface{}) (*User, error)
+	Save(u *User) error
+}
@@ -0,0 +1,101 @@
+// Package video provides a VideoService implementation that shells out to
+// ffmpeg to pull a single still frame out of a video file.
+package video
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ErrFFmpegNotFound is returned when the ffmpeg binary can't be located on
+// PATH, so callers can disable video previews instead of failing requests.
+var ErrFFmpegNotFound = errors.New("ffmpeg binary not found")
+
+// Service extracts frames from video files using the ffmpeg CLI.
+type Service struct {
+	ffmpegPath string
+}
+
+// NewService locates ffmpeg on PATH and returns a ready to use Service. It
+// returns ErrFFmpegNotFound if ffmpeg isn't installed, so operators without
+// it can still run filebrowser with video previews disabled.
+func NewService() (*Service, error) {
+	path, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, ErrFFmpegNotFound
+	}
+	return &Service{ffmpegPath: path}, nil
+}
+
+// Frame writes a JPEG frame taken at offset (0.0-1.0, fraction of the video
+// duration) to out. in is read fully into a temp file because ffmpeg needs
+// to seek before decoding.
+func (s *Service) Frame(ctx context.Context, in io.Reader, offset float64, out io.Writer) error {
+	if offset < 0 || offset > 1 {
+		return fmt.Errorf("video: offset must be between 0 and 1, got %f", offset)
+	}
+
+	tmp, err := os.CreateTemp("", "filebrowser-video-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		return err
+	}
+
+	duration, err := s.probeDuration(ctx, tmp.Name())
+	if err != nil {
+		return err
+	}
+
+	seek := fmt.Sprintf("%.3f", duration*offset)
+
+	var stderr bytes.Buffer
+	// #nosec G204 -- arguments are built from a fixed template, not user input
+	cmd := exec.CommandContext(ctx, s.ffmpegPath,
+		"-ss", seek,
+		"-i", tmp.Name(),
+		"-frames:v", "1",
+		"-f", "image2",
+		"-vcodec", "mjpeg",
+		"-",
+	)
+	cmd.Stdout = out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("video: ffmpeg failed: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+func (s *Service) probeDuration(ctx context.Context, path string) (float64, error) {
+	var stdout, stderr bytes.Buffer
+	// #nosec G204 -- arguments are built from a fixed template, not user input
+	cmd := exec.CommandContext(ctx, s.ffmpegPath, "-i", path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// ffmpeg without -f null writes metadata to stderr and exits non-zero
+	// when no output is requested; that's expected here, we just want the
+	// duration line.
+	_ = cmd.Run()
+
+	idx := strings.Index(stderr.String(), "Duration: ")
+	if idx < 0 {
+		return 0, fmt.Errorf("video: could not determine duration: no Duration line in ffmpeg output")
+	}
+
+	var h, m, sec int
+	if _, err := fmt.Sscanf(stderr.String()[idx+len("Duration: "):], "%d:%d:%d", &h, &m, &sec); err != nil {
+		return 0, fmt.Errorf("video: could not determine duration: %w", err)
+	}
+	return float64(h*3600 + m*60 + sec), nil
+}